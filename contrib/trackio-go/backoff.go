@@ -2,25 +2,42 @@ package trackio
 
 // backoff.go
 //
-// withBackoff retries fn with exponential backoff and jitter.
-// Currently unused, but planned for retry logic in postJSON / batcher.flush().
+// withBackoff retries fn with exponential backoff and jitter, stopping
+// early on a terminal error (see isTerminal) or context cancellation, and
+// honoring a server-supplied Retry-After when the error carries one.
 
 import (
+	"context"
 	"math/rand"
 	"time"
 )
 
-func withBackoff(fn func() error, maxRetries int) error {
+func withBackoff(ctx context.Context, fn func() error, maxRetries int) error {
 	var err error
 	base := 50 * time.Millisecond
-	for i := 0; i <= maxRetries; i++ {
+	for i := 0; ; i++ {
 		err = fn()
 		if err == nil {
 			return nil
 		}
+		if isTerminal(err) {
+			return err
+		}
+		if i >= maxRetries {
+			return err
+		}
+
 		d := base * time.Duration(1<<i)
-		jitter := time.Duration(rand.Int63n(int64(d / 2)))
-		time.Sleep(d/2 + jitter)
+		jitter := time.Duration(rand.Int63n(int64(d/2 + 1)))
+		wait := d/2 + jitter
+		if se, ok := asHTTPStatusError(err); ok && se.RetryAfter > 0 {
+			wait = se.RetryAfter
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 	}
-	return err
 }
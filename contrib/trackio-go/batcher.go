@@ -9,25 +9,93 @@ import (
 	"time"
 )
 
+const defaultQueueSize = 10000
+const defaultCommitQueueSize = 256
+
+// batcher owns the durable spool plus an in-memory ring of the items
+// still awaiting delivery. enqueueContext() appends to the spool first so
+// nothing is lost if the process dies before the next flush; the ring
+// just lets loop() batch recently-spooled items without re-reading them
+// from disk on every tick. sem bounds how many items can be in flight
+// (spooled-but-unacked) at once; enqueueContext blocks on it to apply
+// backpressure once the queue is full.
 type batcher struct {
 	c        *Client
 	mu       sync.Mutex
-	buf      []LogItem
+	ring     []pendingItem
+	retry    []pendingItem // failed batches awaiting redelivery, oldest first; drained before ring
 	maxBatch int
 	ticker   *time.Ticker
 	stopped  chan struct{}
+
+	sem chan struct{}
+
+	// commitCh carries claimed batches in claim order so ackBatch can
+	// advance the spool checkpoint in that same order even though the
+	// deliveries themselves (one per flush call) run fully concurrently
+	// through the delivery worker pool. Without this, two flushes
+	// finishing out of claim order could advance the checkpoint past a
+	// batch that's still undelivered.
+	commitCh      chan *claimedBatch
+	committerDone chan struct{}
+
+	spool *spool // nil if the spool failed to open; batching falls back to in-memory only
+}
+
+// claimedBatch is a batch of items a flush has already trimmed out of the
+// ring/retry queues, paired with a channel for its eventual delivery
+// result so the committer goroutine can ack (or retry) it in claim order.
+type claimedBatch struct {
+	items  []pendingItem
+	result chan error
 }
 
 func newBatcher(c *Client) *batcher {
 	max := envInt("TRACKIO_MAX_BATCH", 128)
 	interval := time.Duration(envInt("TRACKIO_FLUSH_INTERVAL_MS", 200)) * time.Millisecond
+
+	maxSpoolSize := int64(envInt("TRACKIO_MAX_SPOOL_BYTES", 64<<20))
+	fsyncEvery := envInt("TRACKIO_SPOOL_FSYNC_EVERY", 1)
+	overflow := parseOverflowPolicy(os.Getenv("TRACKIO_SPOOL_OVERFLOW"))
+
+	var sp *spool
+	var pending []pendingItem
+	dir := defaultSpoolDir(c.project, c.run)
+	opened, recovered, err := openSpool(dir, maxSpoolSize, fsyncEvery, overflow)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "trackio: spool disabled, falling back to in-memory batching: %v\n", err)
+	} else {
+		sp = opened
+		pending = recovered
+	}
+
+	queueSize := c.queueSize
+	if queueSize < 1 {
+		queueSize = defaultQueueSize
+	}
+	if len(pending) > queueSize {
+		// Resuming more than the configured queue size; size the queue to
+		// fit what's already spooled rather than deadlocking on startup.
+		queueSize = len(pending)
+	}
+	sem := make(chan struct{}, queueSize)
+	for range pending {
+		sem <- struct{}{}
+	}
+
 	b := &batcher{
-		c:        c,
-		maxBatch: max,
-		ticker:   time.NewTicker(interval),
-		stopped:  make(chan struct{}),
+		c:             c,
+		spool:         sp,
+		ring:          pending,
+		maxBatch:      max,
+		ticker:        time.NewTicker(interval),
+		stopped:       make(chan struct{}),
+		sem:           sem,
+		commitCh:      make(chan *claimedBatch, defaultCommitQueueSize),
+		committerDone: make(chan struct{}),
 	}
 	go b.loop()
+	go b.committer()
 	return b
 }
 
@@ -42,31 +110,161 @@ func (b *batcher) loop() {
 	}
 }
 
-func (b *batcher) enqueue(it LogItem) {
+// enqueueContext waits for room in the bounded queue (respecting ctx),
+// durably spools it (if a spool is available), and adds it to the hot
+// ring, flushing immediately once the ring reaches maxBatch.
+func (b *batcher) enqueueContext(ctx context.Context, it LogItem) error {
+	select {
+	case b.sem <- struct{}{}:
+	default:
+		if ctx.Err() != nil {
+			return ErrQueueFull
+		}
+		select {
+		case b.sem <- struct{}{}:
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				return ErrDeadlineExceeded
+			}
+			return ctx.Err()
+		}
+	}
+
+	var at mark
+	if b.spool != nil {
+		var err error
+		at, err = b.spool.append(ctx, it)
+		if err != nil {
+			<-b.sem // release the slot we just claimed; the item never made it in
+			return err
+		}
+	}
+
 	b.mu.Lock()
-	b.buf = append(b.buf, it)
-	shouldFlush := len(b.buf) >= b.maxBatch
+	b.ring = append(b.ring, pendingItem{Item: it, At: at})
+	shouldFlush := len(b.ring) >= b.maxBatch
 	b.mu.Unlock()
+
 	if shouldFlush {
-		_ = b.flush(context.Background())
+		// Use the caller's ctx, not context.Background(): otherwise every
+		// maxBatch'th LogContext call would run a full synchronous
+		// delivery (retries included) ignoring the caller's own
+		// deadline/cancellation.
+		_ = b.flush(ctx)
 	}
+	return nil
 }
 
-func (b *batcher) flush(ctx context.Context) error {
+// claim pulls up to maxBatch items off the retry queue (failed batches
+// awaiting redelivery, oldest first) and then the ring, atomically
+// trimming whatever it takes so two concurrent claims can never overlap.
+func (b *batcher) claim() []pendingItem {
 	b.mu.Lock()
-	items := b.buf
-	b.buf = nil
-	b.mu.Unlock()
+	defer b.mu.Unlock()
 
-	if len(items) == 0 {
+	total := len(b.retry) + len(b.ring)
+	if total == 0 {
 		return nil
 	}
+	n := total
+	if b.maxBatch > 0 && n > b.maxBatch {
+		n = b.maxBatch
+	}
 
-	metricsList := make([]map[string]any, 0, len(items))
-	steps := make([]int, 0, len(items))
-	timestamps := make([]string, 0, len(items))
+	batch := make([]pendingItem, 0, n)
+	if k := minInt(n, len(b.retry)); k > 0 {
+		batch = append(batch, b.retry[:k]...)
+		b.retry = b.retry[k:]
+	}
+	if k := n - len(batch); k > 0 {
+		batch = append(batch, b.ring[:k]...)
+		b.ring = b.ring[k:]
+	}
+	return batch
+}
 
-	for _, it := range items {
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// flush claims the next batch and submits it for delivery, honoring ctx
+// for the submit itself. Separate flush calls submit fully concurrently -
+// that's the point of WithDeliveryWorkers - while the commitCh handoff
+// lets the committer goroutine ack or retry each batch in the order it
+// was claimed, so the spool checkpoint only ever advances past items
+// that are actually delivered, even when a later batch happens to finish
+// first.
+func (b *batcher) flush(ctx context.Context) error {
+	batch := b.claim()
+	if len(batch) == 0 {
+		return nil
+	}
+
+	payload := b.buildPayload(batch)
+	cb := &claimedBatch{items: batch, result: make(chan error, 1)}
+	b.commitCh <- cb
+
+	err := b.c.delivery.submit(ctx, payload)
+	cb.result <- err
+	if err != nil {
+		return fmt.Errorf("trackio: delivery failed: %w", err)
+	}
+	return nil
+}
+
+// committer acks or retries claimed batches strictly in claim order,
+// even though the batches' own deliveries race each other concurrently.
+func (b *batcher) committer() {
+	defer close(b.committerDone)
+	for {
+		select {
+		case cb := <-b.commitCh:
+			b.ackBatch(cb)
+		case <-b.stopped:
+			// Drain whatever's already queued so a batch submitted just
+			// before close() still gets acked (or retried) instead of
+			// silently abandoned.
+			for {
+				select {
+				case cb := <-b.commitCh:
+					b.ackBatch(cb)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (b *batcher) ackBatch(cb *claimedBatch) {
+	if err := <-cb.result; err != nil {
+		// Leave the items spooled and append them to the retry queue,
+		// which claim() drains before the ring; the next flush (ticker or
+		// enqueue-triggered) picks them up again ahead of anything newer.
+		b.mu.Lock()
+		b.retry = append(b.retry, cb.items...)
+		b.mu.Unlock()
+		return
+	}
+
+	if b.spool != nil {
+		b.spool.advance(cb.items[len(cb.items)-1].At)
+	}
+	for range cb.items {
+		<-b.sem
+	}
+}
+
+func (b *batcher) buildPayload(batch []pendingItem) bulkLogPayload {
+	metricsList := make([]map[string]any, 0, len(batch))
+	steps := make([]int, 0, len(batch))
+	timestamps := make([]string, 0, len(batch))
+
+	for _, p := range batch {
+		it := p.Item
 		if it.Metrics == nil {
 			it.Metrics = map[string]any{}
 		}
@@ -79,30 +277,26 @@ func (b *batcher) flush(ctx context.Context) error {
 			steps = append(steps, -1)
 		}
 
-		if it.Timestamp == "" {
-			timestamps = append(timestamps, "")
-		} else {
-			timestamps = append(timestamps, it.Timestamp)
-		}
+		timestamps = append(timestamps, it.Timestamp)
 	}
 
-	payload := bulkLogPayload{
+	return bulkLogPayload{
 		Project:     b.c.project,
 		Run:         b.c.run,
 		MetricsList: metricsList,
 		Steps:       steps,
 		Timestamps:  timestamps,
-		// Config:    nil, // set if you want to send config once
 	}
+}
 
-	// Try modern REST route first, then legacy gradio route
-	if err := b.c.tryPost(ctx, "/api/bulk_log", payload); err == nil {
-		return nil
-	}
-	if err := b.c.tryPost(ctx, "/gradio_api/bulk_log", payload); err == nil {
-		return nil
+func (b *batcher) close() error {
+	b.ticker.Stop()
+	close(b.stopped)
+	<-b.committerDone
+	if b.spool != nil {
+		return b.spool.close()
 	}
-	return fmt.Errorf("trackio: unable to POST to either /api/bulk_log or /gradio_api/bulk_log")
+	return nil
 }
 
 func envInt(k string, def int) int {
@@ -0,0 +1,122 @@
+package trackio
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestBatcherConcurrentFlushDoesNotPanic reproduces the scenario where the
+// ticker in loop() and an enqueue-triggered flush race against the same
+// ring: with a slow server and a small TRACKIO_MAX_BATCH, overlapping
+// flush() calls used to read the same ring[:n] prefix and then both trim
+// it, panicking with a slice-bounds-out-of-range once the second trim's n
+// outran the already-shrunk ring.
+func TestBatcherConcurrentFlushDoesNotPanic(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	t.Setenv("TRACKIO_MAX_BATCH", "2")
+	t.Setenv("TRACKIO_FLUSH_INTERVAL_MS", "5")
+	t.Setenv("TRACKIO_SPOOL_DIR", t.TempDir())
+
+	c := New(WithBaseURL(srv.URL), WithProject("p"), WithRun("r"), WithTimeout(time.Second))
+	defer c.Close()
+
+	for i := 0; i < 50; i++ {
+		step := i
+		c.Log(map[string]any{"loss": float64(i)}, &step, "")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := c.Flush(ctx); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+}
+
+// TestEnqueueContextTriggeredFlushHonorsCtxDeadline reproduces the case
+// where maxBatch'th LogContext call runs its triggered flush against the
+// caller's own ctx: with TRACKIO_MAX_BATCH=1 and a server that hangs well
+// past the caller's deadline, LogContext must still return promptly
+// instead of blocking for the full delivery (including retries), which is
+// what happened when the triggered flush was hardcoded to
+// context.Background().
+func TestEnqueueContextTriggeredFlushHonorsCtxDeadline(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Second)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	t.Setenv("TRACKIO_MAX_BATCH", "1")
+	t.Setenv("TRACKIO_FLUSH_INTERVAL_MS", "100000") // keep the ticker from racing the test
+	t.Setenv("TRACKIO_SPOOL_DIR", t.TempDir())
+	t.Setenv("TRACKIO_MAX_RETRIES", "0")
+
+	c := New(WithBaseURL(srv.URL), WithProject("p"), WithRun("r"), WithTimeout(time.Second))
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	step := 0
+	start := time.Now()
+	_ = c.LogContext(ctx, map[string]any{"x": 1.0}, &step, "")
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("LogContext took %v, want it to respect the 100ms ctx deadline", elapsed)
+	}
+}
+
+// TestFlushDeliversBatchesConcurrently guards against flush() over-
+// serializing: previously a flushMu held for the whole flush() body
+// (including the delivery.submit call) meant the batcher never had more
+// than one bulkLogPayload in flight at a time, making WithDeliveryWorkers
+// a no-op beyond the first worker. With TRACKIO_MAX_BATCH=1, each of
+// these concurrent Log calls claims its own single-item batch and should
+// be free to have its delivery in flight alongside the others.
+func TestFlushDeliversBatchesConcurrently(t *testing.T) {
+	var cur, peak int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt64(&cur, 1)
+		for {
+			p := atomic.LoadInt64(&peak)
+			if n <= p || atomic.CompareAndSwapInt64(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(100 * time.Millisecond)
+		atomic.AddInt64(&cur, -1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	t.Setenv("TRACKIO_MAX_BATCH", "1")
+	t.Setenv("TRACKIO_FLUSH_INTERVAL_MS", "100000") // keep the ticker from racing the test
+	t.Setenv("TRACKIO_SPOOL_DIR", t.TempDir())
+
+	c := New(WithBaseURL(srv.URL), WithProject("p"), WithRun("r"), WithDeliveryWorkers(4), WithTimeout(2*time.Second))
+	defer c.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		step := i
+		go func() {
+			defer wg.Done()
+			c.Log(map[string]any{"x": float64(step)}, &step, "")
+		}()
+	}
+	wg.Wait()
+
+	if got := atomic.LoadInt64(&peak); got < 2 {
+		t.Fatalf("peak concurrent deliveries = %d, want >= 2 (WithDeliveryWorkers(4) should allow overlap)", got)
+	}
+}
@@ -1,7 +1,6 @@
 package trackio
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -12,12 +11,18 @@ import (
 )
 
 type Client struct {
-	baseURL    string
-	project    string
-	run        string
-	writeToken string
-	http       *http.Client
-	batcher    *batcher
+	baseURL         string
+	project         string
+	run             string
+	writeToken      string
+	http            *http.Client
+	batcher         *batcher
+	deliveryWorkers int
+	queueSize       int
+	delivery        *deliveryPool
+	health          *hostHealth
+	deadline        *deadlineTimer
+	writeDeadline   *deadlineTimer
 }
 
 type Option func(*Client)
@@ -36,38 +41,139 @@ func WithTimeout(d time.Duration) Option {
 }
 func WithWriteToken(tok string) Option { return func(c *Client) { c.writeToken = tok } }
 
+// WithDeliveryWorkers sets the number of goroutines concurrently POSTing
+// batches to the server. Defaults to TRACKIO_DELIVERY_WORKERS or 4.
+func WithDeliveryWorkers(n int) Option {
+	return func(c *Client) { c.deliveryWorkers = n }
+}
+
+// WithQueueSize bounds how many not-yet-delivered items LogContext will
+// admit before it blocks (or returns ErrQueueFull/ErrDeadlineExceeded,
+// depending on the ctx it's given room to wait on). Defaults to
+// TRACKIO_QUEUE_SIZE or 10000.
+func WithQueueSize(n int) Option {
+	return func(c *Client) { c.queueSize = n }
+}
+
 func New(opts ...Option) *Client {
 	c := &Client{
-		baseURL:    getenv("TRACKIO_SERVER_URL", "http://127.0.0.1:7860"),
-		project:    os.Getenv("TRACKIO_PROJECT"),
-		run:        os.Getenv("TRACKIO_RUN"),
-		writeToken: os.Getenv("TRACKIO_WRITE_TOKEN"),
-		http:       &http.Client{Timeout: 5 * time.Second},
+		baseURL:         getenv("TRACKIO_SERVER_URL", "http://127.0.0.1:7860"),
+		project:         os.Getenv("TRACKIO_PROJECT"),
+		run:             os.Getenv("TRACKIO_RUN"),
+		writeToken:      os.Getenv("TRACKIO_WRITE_TOKEN"),
+		http:            &http.Client{Timeout: 5 * time.Second},
+		deliveryWorkers: envInt("TRACKIO_DELIVERY_WORKERS", defaultDeliveryWorkers),
+		queueSize:       envInt("TRACKIO_QUEUE_SIZE", defaultQueueSize),
 	}
 	for _, opt := range opts {
 		opt(c)
 	}
+	c.health = newHostHealth(
+		envInt("TRACKIO_BAD_HOST_THRESHOLD", defaultBadHostThreshold),
+		time.Duration(envInt("TRACKIO_BAD_HOST_COOLDOWN_MS", int(defaultBadHostCooldown/time.Millisecond)))*time.Millisecond,
+	)
+	c.deadline = newDeadlineTimer()
+	c.writeDeadline = newDeadlineTimer()
+	c.delivery = newDeliveryPool(c, c.deliveryWorkers)
 	c.batcher = newBatcher(c)
 	return c
 }
 
+// SetDeadline caps how long any single delivery POST (across both
+// attempted routes, and each retry) may run; a flush exceeding it fails
+// with ErrDeadlineExceeded. The zero Time disarms it. Mirrors
+// net.Conn.SetDeadline in also setting the write deadline.
+func (c *Client) SetDeadline(t time.Time) {
+	c.deadline.set(t)
+	c.writeDeadline.set(t)
+}
+
+// SetWriteDeadline caps how long any single delivery POST may run,
+// without touching the general deadline set by SetDeadline.
+func (c *Client) SetWriteDeadline(t time.Time) {
+	c.writeDeadline.set(t)
+}
+
+// withWriteDeadline returns a context derived from ctx that's also
+// canceled once the write or general deadline elapses, plus a cleanup
+// func callers must always invoke to release the watcher goroutine.
+func (c *Client) withWriteDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	wch := c.writeDeadline.channel()
+	gch := c.deadline.channel()
+	cctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-wch:
+		case <-gch:
+		case <-done:
+		}
+		cancel()
+	}()
+	return cctx, func() {
+		close(done)
+		cancel()
+	}
+}
+
+// Health reports the current delivery health of every base URL this
+// client has seen a failure against. A host absent from the map has no
+// known problems.
+func (c *Client) Health() map[string]HostStatus {
+	return c.health.snapshot()
+}
+
+// Log enqueues metrics for delivery, blocking until there's room in the
+// bounded queue. Use LogContext directly if you need the call to respect
+// a deadline instead of blocking indefinitely.
 func (c *Client) Log(metrics map[string]any, step *int, ts string) {
-	c.batcher.enqueue(LogItem{Timestamp: ts, Step: step, Metrics: metrics})
+	if err := c.LogContext(context.Background(), metrics, step, ts); err != nil {
+		fmt.Fprintf(os.Stderr, "trackio: Log: %v\n", err)
+	}
+}
+
+// LogContext enqueues metrics for delivery, waiting for room in the
+// bounded queue (see WithQueueSize) only as long as ctx allows. It
+// returns ErrQueueFull if the queue is full and ctx offers no time to
+// wait, or ErrDeadlineExceeded if ctx expires while waiting.
+func (c *Client) LogContext(ctx context.Context, metrics map[string]any, step *int, ts string) error {
+	return c.batcher.enqueueContext(ctx, LogItem{Timestamp: ts, Step: step, Metrics: metrics})
 }
 
 func (c *Client) Flush(ctx context.Context) error {
 	return c.batcher.flush(ctx)
 }
 
-// Close flushes outstanding logs with a short background timeout.
+// Close flushes outstanding logs with a short background timeout and
+// stops the batcher and its delivery workers.
 // Safe to use as `defer client.Close()`.
 func (c *Client) Close() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
-	return c.Flush(ctx)
+	// Each Flush claims at most one batch, so keep flushing until nothing
+	// is left queued or a flush fails.
+	var flushErr error
+	for {
+		c.batcher.mu.Lock()
+		left := len(c.batcher.ring) + len(c.batcher.retry)
+		c.batcher.mu.Unlock()
+		if left == 0 {
+			break
+		}
+		if flushErr = c.Flush(ctx); flushErr != nil {
+			break
+		}
+	}
+	closeErr := c.batcher.close()
+	c.delivery.close()
+	if flushErr != nil {
+		return flushErr
+	}
+	return closeErr
 }
 
-// postJSON sends JSON to baseURL+path and returns a verbose error on non-2xx.
+// tryPost sends JSON to baseURL+path and returns a verbose *httpStatusError
+// on non-2xx.
 func (c *Client) tryPost(ctx context.Context, path string, payload any) error {
 	url := c.baseURL + path
 	b, err := json.Marshal(payload)
@@ -75,52 +181,23 @@ func (c *Client) tryPost(ctx context.Context, path string, payload any) error {
 		return err
 	}
 
-	do := func(u string) (*http.Response, error) {
-		req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(b))
-		if err != nil {
-			return nil, err
-		}
-		req.Header.Set("Content-Type", "application/json")
-		if c.writeToken != "" {
-			req.Header.Set("X-Trackio-Write-Token", c.writeToken)
-		}
-		return c.http.Do(req)
+	headers := map[string]string{"Content-Type": "application/json"}
+	if c.writeToken != "" {
+		headers["X-Trackio-Write-Token"] = c.writeToken
 	}
 
-	// first attempt
-	resp, err := do(url)
+	resp, err := doWithRedirect(ctx, c.http, http.MethodPost, url, b, headers)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
-	// handle redirect-on-POST (preserve method & body)
-	if resp.StatusCode == http.StatusMovedPermanently || // 301
-		resp.StatusCode == http.StatusFound || // 302
-		resp.StatusCode == http.StatusSeeOther || // 303
-		resp.StatusCode == http.StatusTemporaryRedirect || // 307
-		resp.StatusCode == http.StatusPermanentRedirect { // 308
-
-		loc := resp.Header.Get("Location")
-		if loc != "" {
-			// one re-post to the redirected location
-			resp.Body.Close()
-			resp2, err2 := do(loc)
-			if err2 != nil {
-				return err2
-			}
-			defer resp2.Body.Close()
-			if resp2.StatusCode >= 300 {
-				body, _ := io.ReadAll(resp2.Body)
-				return fmt.Errorf("POST %s -> %s; body: %s", loc, resp2.Status, string(body))
-			}
-			return nil
-		}
-	}
-
 	if resp.StatusCode >= 300 {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("POST %s -> %s; body: %s", url, resp.Status, string(body))
+		return &httpStatusError{
+			URL: url, StatusCode: resp.StatusCode, Status: resp.Status, Body: string(body),
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		}
 	}
 	return nil
 }
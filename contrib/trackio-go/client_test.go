@@ -0,0 +1,140 @@
+package trackio
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestLogContextReturnsErrQueueFullWithoutBlocking checks the
+// drop-or-block contract LogContext promises: once the bounded queue is
+// full, a call with an already-expired context must fail fast with
+// ErrQueueFull rather than wait.
+func TestLogContextReturnsErrQueueFullWithoutBlocking(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	t.Setenv("TRACKIO_FLUSH_INTERVAL_MS", "100000") // keep the ticker from racing the test
+	t.Setenv("TRACKIO_SPOOL_DIR", t.TempDir())
+
+	c := New(WithBaseURL(srv.URL), WithProject("p"), WithRun("r"), WithQueueSize(1), WithTimeout(time.Second))
+	defer c.Close()
+
+	step := 0
+	if err := c.LogContext(context.Background(), map[string]any{"x": 1.0}, &step, ""); err != nil {
+		t.Fatalf("first LogContext: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel() // already done: no time left to wait for room
+
+	if err := c.LogContext(ctx, map[string]any{"x": 2.0}, &step, ""); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("expected ErrQueueFull, got %v", err)
+	}
+}
+
+// TestClientHealthReflectsQuarantine checks that Health() surfaces a host
+// as quarantined once enough consecutive failures cross the configured
+// threshold, and that a subsequent success clears it.
+func TestClientHealthReflectsQuarantine(t *testing.T) {
+	var failing atomic.Bool
+	failing.Store(true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	t.Setenv("TRACKIO_BAD_HOST_THRESHOLD", "1")
+	t.Setenv("TRACKIO_BAD_HOST_COOLDOWN_MS", "50")
+	t.Setenv("TRACKIO_MAX_RETRIES", "0")
+	t.Setenv("TRACKIO_SPOOL_DIR", t.TempDir())
+
+	c := New(WithBaseURL(srv.URL), WithProject("p"), WithRun("r"), WithTimeout(time.Second))
+	defer c.Close()
+
+	if err := c.delivery.deliver(context.Background(), bulkLogPayload{Project: "p", Run: "r"}); err == nil {
+		t.Fatal("expected the failing server to produce a delivery error")
+	}
+
+	status, ok := c.Health()[srv.URL]
+	if !ok || !status.Quarantined {
+		t.Fatalf("Health() = %+v, want %s quarantined after crossing the threshold", c.Health(), srv.URL)
+	}
+
+	failing.Store(false)
+	time.Sleep(60 * time.Millisecond) // let the cooldown elapse so the next attempt can probe
+	if err := c.delivery.deliver(context.Background(), bulkLogPayload{Project: "p", Run: "r"}); err != nil {
+		t.Fatalf("recovery deliver: %v", err)
+	}
+	if _, ok := c.Health()[srv.URL]; ok {
+		t.Fatalf("Health() still reports %s after a successful delivery cleared it", srv.URL)
+	}
+}
+
+// TestSetDeadlineAbortsSlowDelivery checks that SetDeadline caps how long
+// a delivery POST may run, failing it with ErrDeadlineExceeded instead of
+// waiting for the server.
+func TestSetDeadlineAbortsSlowDelivery(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	t.Setenv("TRACKIO_SPOOL_DIR", t.TempDir())
+	t.Setenv("TRACKIO_MAX_RETRIES", "0")
+
+	c := New(WithBaseURL(srv.URL), WithProject("p"), WithRun("r"), WithTimeout(2*time.Second))
+	defer c.Close()
+	c.SetDeadline(time.Now().Add(100 * time.Millisecond))
+
+	start := time.Now()
+	err := c.delivery.deliver(context.Background(), bulkLogPayload{Project: "p", Run: "r"})
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, ErrDeadlineExceeded) {
+		t.Fatalf("deliver: got %v, want ErrDeadlineExceeded", err)
+	}
+	if elapsed > 500*time.Millisecond {
+		t.Fatalf("deliver took %v, want it to respect the 100ms deadline", elapsed)
+	}
+}
+
+// TestSetWriteDeadlineAbortsSlowDelivery checks that SetWriteDeadline
+// alone (without SetDeadline) also caps a delivery POST.
+func TestSetWriteDeadlineAbortsSlowDelivery(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	t.Setenv("TRACKIO_SPOOL_DIR", t.TempDir())
+	t.Setenv("TRACKIO_MAX_RETRIES", "0")
+
+	c := New(WithBaseURL(srv.URL), WithProject("p"), WithRun("r"), WithTimeout(2*time.Second))
+	defer c.Close()
+	c.SetWriteDeadline(time.Now().Add(100 * time.Millisecond))
+
+	start := time.Now()
+	err := c.delivery.deliver(context.Background(), bulkLogPayload{Project: "p", Run: "r"})
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, ErrDeadlineExceeded) {
+		t.Fatalf("deliver: got %v, want ErrDeadlineExceeded", err)
+	}
+	if elapsed > 500*time.Millisecond {
+		t.Fatalf("deliver took %v, want it to respect the 100ms write deadline", elapsed)
+	}
+}
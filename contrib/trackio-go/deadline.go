@@ -0,0 +1,58 @@
+package trackio
+
+// deadline.go
+//
+// deadlineTimer arms a "this deadline has passed" channel the way
+// net.Conn's internal deadlineTimer does: each call to set() stops the
+// previous timer before arming a new one, and swaps in a fresh channel
+// rather than trying to un-close an old one, so repeated SetDeadline calls
+// neither leak timers nor race a timer firing against it being reset.
+
+import (
+	"sync"
+	"time"
+)
+
+type deadlineTimer struct {
+	mu     sync.Mutex
+	timer  *time.Timer
+	cancel chan struct{}
+}
+
+func newDeadlineTimer() *deadlineTimer {
+	return &deadlineTimer{cancel: make(chan struct{})}
+}
+
+// set arms the deadline at t, or disarms it if t is the zero Time. It
+// installs a fresh channel (see channel()) that is closed when the
+// deadline fires; that channel is only ever closed once, so it's safe to
+// read from repeatedly.
+func (d *deadlineTimer) set(t time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	d.cancel = make(chan struct{})
+
+	if t.IsZero() {
+		return
+	}
+	ch := d.cancel
+	dur := time.Until(t)
+	if dur <= 0 {
+		close(ch)
+		return
+	}
+	d.timer = time.AfterFunc(dur, func() { close(ch) })
+}
+
+// channel returns the current "deadline exceeded" channel. Callers must
+// re-fetch it after each set() rather than caching it across calls.
+func (d *deadlineTimer) channel() <-chan struct{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.cancel
+}
@@ -0,0 +1,133 @@
+package trackio
+
+// delivery.go
+//
+// A small pool of delivery workers drains bulk-log jobs the batcher
+// submits, so a flush blocked on a slow or down host doesn't stall every
+// other flush. Each worker retries transient failures with backoff
+// (withBackoff) and reports the outcome to hostHealth so a consistently
+// failing host gets quarantined instead of hammered.
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+const (
+	defaultDeliveryWorkers = 4
+	defaultDeliveryRetries = 4
+)
+
+type deliveryJob struct {
+	ctx     context.Context
+	payload bulkLogPayload
+	done    chan error
+}
+
+type deliveryPool struct {
+	c          *Client
+	maxRetries int
+	jobs       chan deliveryJob
+	wg         sync.WaitGroup
+	stopped    chan struct{}
+}
+
+func newDeliveryPool(c *Client, workers int) *deliveryPool {
+	if workers < 1 {
+		workers = defaultDeliveryWorkers
+	}
+	p := &deliveryPool{
+		c:          c,
+		maxRetries: envInt("TRACKIO_MAX_RETRIES", defaultDeliveryRetries),
+		jobs:       make(chan deliveryJob, workers),
+		stopped:    make(chan struct{}),
+	}
+	p.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go p.work()
+	}
+	return p
+}
+
+func (p *deliveryPool) work() {
+	defer p.wg.Done()
+	for {
+		select {
+		case job := <-p.jobs:
+			job.done <- p.deliver(job.ctx, job.payload)
+		case <-p.stopped:
+			return
+		}
+	}
+}
+
+// deliver POSTs payload, preferring the modern REST route and falling
+// back to the legacy gradio route, retrying transient failures with
+// backoff. It skips the attempt entirely if the target host is currently
+// quarantined.
+func (p *deliveryPool) deliver(ctx context.Context, payload bulkLogPayload) error {
+	host := p.c.baseURL
+	if !p.c.health.allow(host) {
+		return fmt.Errorf("trackio: host %s is quarantined, skipping delivery attempt", host)
+	}
+
+	// Always fall back to the legacy route on any modern-route failure -
+	// including a terminal 4xx, since the exact case the fallback exists
+	// for is an older server that 404s /api/bulk_log entirely.
+	// isTerminal only gates whether withBackoff retries the attempt as a
+	// whole, not whether the legacy route gets a try.
+	attempt := func() error {
+		if err := p.post(ctx, "/api/bulk_log", payload); err == nil {
+			return nil
+		}
+		return p.post(ctx, "/gradio_api/bulk_log", payload)
+	}
+
+	err := withBackoff(ctx, attempt, p.maxRetries)
+	switch {
+	case err == nil:
+		p.c.health.recordSuccess(host)
+	case isRetryable(err):
+		p.c.health.recordFailure(host)
+	}
+	return err
+}
+
+// post POSTs payload, capping the attempt at the client's write/general
+// deadline (if any) in addition to ctx, and translating a deadline-caused
+// cancellation into ErrDeadlineExceeded.
+func (p *deliveryPool) post(ctx context.Context, path string, payload bulkLogPayload) error {
+	dctx, cancel := p.c.withWriteDeadline(ctx)
+	defer cancel()
+
+	err := p.c.tryPost(dctx, path, payload)
+	if err != nil && dctx.Err() != nil && ctx.Err() == nil {
+		return ErrDeadlineExceeded
+	}
+	return err
+}
+
+// submit hands payload to the worker pool and blocks until it's
+// delivered or ctx is done.
+func (p *deliveryPool) submit(ctx context.Context, payload bulkLogPayload) error {
+	job := deliveryJob{ctx: ctx, payload: payload, done: make(chan error, 1)}
+	select {
+	case p.jobs <- job:
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-p.stopped:
+		return fmt.Errorf("trackio: delivery pool is closed")
+	}
+	select {
+	case err := <-job.done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *deliveryPool) close() {
+	close(p.stopped)
+	p.wg.Wait()
+}
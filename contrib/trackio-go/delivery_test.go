@@ -0,0 +1,35 @@
+package trackio
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestDeliverFallsBackToLegacyRouteOnTerminalError exercises the case the
+// legacy route exists for: an older server that 404s the modern
+// /api/bulk_log route entirely. A non-429 4xx is "terminal" for retry
+// purposes, but that must only skip retrying /api/bulk_log itself, not
+// skip trying /gradio_api/bulk_log.
+func TestDeliverFallsBackToLegacyRouteOnTerminalError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/bulk_log":
+			w.WriteHeader(http.StatusNotFound)
+		case "/gradio_api/bulk_log":
+			w.WriteHeader(http.StatusOK)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	c := New(WithBaseURL(srv.URL), WithProject("p"), WithRun("r"))
+	defer c.Close()
+
+	err := c.delivery.deliver(context.Background(), bulkLogPayload{Project: "p", Run: "r"})
+	if err != nil {
+		t.Fatalf("expected legacy route fallback to succeed, got: %v", err)
+	}
+}
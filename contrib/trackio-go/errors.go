@@ -0,0 +1,82 @@
+package trackio
+
+// errors.go
+//
+// httpStatusError carries enough of the failed response to classify it as
+// retryable (network errors, 5xx, 429) or terminal (any other 4xx), and
+// to honor a server-supplied Retry-After.
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ErrQueueFull is returned by Client.LogContext when the bounded queue is
+// at capacity and ctx offers no time to wait for room.
+var ErrQueueFull = errors.New("trackio: queue is full")
+
+// ErrDeadlineExceeded is returned by Client.LogContext when a caller's
+// context expires while waiting for queue room, and by a flush whose
+// delivery POST outran Client.SetDeadline/SetWriteDeadline.
+var ErrDeadlineExceeded = errors.New("trackio: deadline exceeded")
+
+type httpStatusError struct {
+	URL        string
+	StatusCode int
+	Status     string
+	Body       string
+	RetryAfter time.Duration
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("POST %s -> %s; body: %s", e.URL, e.Status, e.Body)
+}
+
+func asHTTPStatusError(err error) (*httpStatusError, bool) {
+	var se *httpStatusError
+	if errors.As(err, &se) {
+		return se, true
+	}
+	return nil, false
+}
+
+// isRetryable reports whether err is worth retrying: any transport-level
+// failure (DNS, connection refused, timeout, ...), a 429, or a 5xx.
+// Any other 4xx is terminal - retrying won't change a bad request.
+func isRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if se, ok := asHTTPStatusError(err); ok {
+		return se.StatusCode == http.StatusTooManyRequests || se.StatusCode >= 500
+	}
+	return true
+}
+
+func isTerminal(err error) bool {
+	return err != nil && !isRetryable(err)
+}
+
+// parseRetryAfter parses a Retry-After header value, which is either a
+// number of seconds or an HTTP-date. It returns 0 if the header is
+// missing or unparseable.
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
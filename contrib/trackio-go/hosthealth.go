@@ -0,0 +1,99 @@
+package trackio
+
+// hosthealth.go
+//
+// Tracks delivery health per base URL so a consistently failing host
+// (DNS down, a sleeping Space, ...) stops being hammered with retries.
+// After threshold consecutive failures the host is quarantined for
+// cooldown; once cooldown elapses the next delivery attempt doubles as a
+// probe, and a single success clears the quarantine.
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	defaultBadHostThreshold = 5
+	defaultBadHostCooldown  = 30 * time.Second
+)
+
+// HostStatus is a point-in-time snapshot of a base URL's delivery health,
+// returned by Client.Health().
+type HostStatus struct {
+	ConsecutiveFailures int
+	Quarantined         bool
+	QuarantinedUntil    time.Time
+}
+
+type hostHealth struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	hosts     map[string]*hostState
+}
+
+type hostState struct {
+	consecutiveFailures int
+	quarantinedUntil    time.Time
+}
+
+func newHostHealth(threshold int, cooldown time.Duration) *hostHealth {
+	if threshold < 1 {
+		threshold = defaultBadHostThreshold
+	}
+	if cooldown <= 0 {
+		cooldown = defaultBadHostCooldown
+	}
+	return &hostHealth{threshold: threshold, cooldown: cooldown, hosts: map[string]*hostState{}}
+}
+
+// allow reports whether a delivery attempt against host should proceed.
+// Once a quarantine's cooldown has elapsed it returns true so the
+// caller's attempt doubles as a recovery probe.
+func (h *hostHealth) allow(host string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	st, ok := h.hosts[host]
+	if !ok {
+		return true
+	}
+	return !time.Now().Before(st.quarantinedUntil)
+}
+
+func (h *hostHealth) recordSuccess(host string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.hosts, host)
+}
+
+func (h *hostHealth) recordFailure(host string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	st, ok := h.hosts[host]
+	if !ok {
+		st = &hostState{}
+		h.hosts[host] = st
+	}
+	st.consecutiveFailures++
+	if st.consecutiveFailures >= h.threshold {
+		st.quarantinedUntil = time.Now().Add(h.cooldown)
+	}
+}
+
+// snapshot returns the current health of every host with a tracked
+// failure, keyed by base URL.
+func (h *hostHealth) snapshot() map[string]HostStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	out := make(map[string]HostStatus, len(h.hosts))
+	now := time.Now()
+	for host, st := range h.hosts {
+		out[host] = HostStatus{
+			ConsecutiveFailures: st.consecutiveFailures,
+			Quarantined:         now.Before(st.quarantinedUntil),
+			QuarantinedUntil:    st.quarantinedUntil,
+		}
+	}
+	return out
+}
@@ -0,0 +1,70 @@
+package trackio
+
+// httpx.go
+//
+// doWithRedirect is the redirect-preserving request helper shared by
+// Client.tryPost and Reader: net/http's default redirect handling drops
+// the request body (and turns POST into GET) on 301/302/303, which is
+// wrong for our write APIs and unnecessary for our read ones, so both
+// sides do a single manual re-request instead.
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+)
+
+func isRedirectStatus(code int) bool {
+	switch code {
+	case http.StatusMovedPermanently, // 301
+		http.StatusFound,             // 302
+		http.StatusSeeOther,          // 303
+		http.StatusTemporaryRedirect, // 307
+		http.StatusPermanentRedirect: // 308
+		return true
+	default:
+		return false
+	}
+}
+
+// doWithRedirect issues method against url with the given body and
+// headers, following at most one redirect while preserving both.
+func doWithRedirect(ctx context.Context, hc *http.Client, method, url string, body []byte, headers map[string]string) (*http.Response, error) {
+	// Stop hc from following redirects itself, so the 3xx reaches the
+	// manual re-request below with method and body intact.
+	noFollow := *hc
+	noFollow.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	hc = &noFollow
+
+	do := func(u string) (*http.Response, error) {
+		var r io.Reader
+		if body != nil {
+			r = bytes.NewReader(body)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, u, r)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		return hc.Do(req)
+	}
+
+	resp, err := do(url)
+	if err != nil {
+		return nil, err
+	}
+
+	if isRedirectStatus(resp.StatusCode) {
+		if loc := resp.Header.Get("Location"); loc != "" {
+			if u, err := resp.Request.URL.Parse(loc); err == nil {
+				loc = u.String() // resolve a relative Location
+			}
+			resp.Body.Close()
+			return do(loc)
+		}
+	}
+	return resp, nil
+}
@@ -14,3 +14,34 @@ type LogItem struct {
 	Step      *int           `json:"step,omitempty"`
 	Metrics   map[string]any `json:"metrics"`
 }
+
+// RunInfo describes one run as returned by Reader.List.
+type RunInfo struct {
+	Project  string `json:"project"`
+	Run      string `json:"run"`
+	LastStep int    `json:"last_step"`
+}
+
+// ProjectFilter narrows Reader.List to a single project. The zero value
+// matches every project the server knows about.
+type ProjectFilter struct {
+	Project string
+}
+
+// metricsPage is the long-polling response shape for
+// /api/runs/{project}/{run}/metrics: the items observed since the
+// requested step, plus the step to resume from on the next poll.
+type metricsPage struct {
+	Items     []LogItem `json:"items"`
+	NextSince int       `json:"next_since"`
+}
+
+// gradioCallEnvelope is the request body for the legacy
+// /gradio_api/call/<name> route, and callStarted its response.
+type gradioCallEnvelope struct {
+	Data []any `json:"data"`
+}
+
+type callStarted struct {
+	EventID string `json:"event_id"`
+}
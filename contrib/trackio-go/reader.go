@@ -0,0 +1,278 @@
+package trackio
+
+// reader.go
+//
+// Reader is trackio-go's read-side counterpart to Client: Subscribe tails
+// a run's metrics and List enumerates runs. Subscribe prefers a
+// Server-Sent-Events stream, falls back to long-polling the same data as
+// plain JSON pages, and finally falls back to the legacy
+// /gradio_api/call/... route gradio itself speaks (the two-step
+// POST-for-event-id, then GET-to-stream protocol, matching the
+// {"data": [...]} envelope used in the quickstart example).
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+type Reader struct {
+	baseURL    string
+	writeToken string
+	http       *http.Client
+}
+
+type ReaderOption func(*Reader)
+
+func WithReaderBaseURL(u string) ReaderOption { return func(r *Reader) { r.baseURL = u } }
+func WithReaderHTTP(h *http.Client) ReaderOption {
+	return func(r *Reader) { r.http = h }
+}
+func WithReaderToken(tok string) ReaderOption { return func(r *Reader) { r.writeToken = tok } }
+
+// NewReader builds a Reader. Unset fields fall back to the same
+// TRACKIO_* environment variables Client uses.
+func NewReader(opts ...ReaderOption) *Reader {
+	r := &Reader{
+		baseURL:    getenv("TRACKIO_SERVER_URL", "http://127.0.0.1:7860"),
+		writeToken: os.Getenv("TRACKIO_WRITE_TOKEN"),
+		http:       &http.Client{}, // no blanket timeout: streaming/long-polling calls are meant to run for a while
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+func (r *Reader) headers(accept string) map[string]string {
+	h := map[string]string{}
+	if accept != "" {
+		h["Accept"] = accept
+	}
+	if r.writeToken != "" {
+		h["X-Trackio-Write-Token"] = r.writeToken
+	}
+	return h
+}
+
+// List returns the runs known to the server, optionally narrowed by filter.
+func (r *Reader) List(ctx context.Context, filter ProjectFilter) ([]RunInfo, error) {
+	u := r.baseURL + "/api/runs"
+	if filter.Project != "" {
+		u += "?project=" + url.QueryEscape(filter.Project)
+	}
+
+	resp, err := doWithRedirect(ctx, r.http, http.MethodGet, u, nil, r.headers("application/json"))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, &httpStatusError{URL: u, StatusCode: resp.StatusCode, Status: resp.Status, Body: string(body)}
+	}
+
+	var runs []RunInfo
+	if err := json.NewDecoder(resp.Body).Decode(&runs); err != nil {
+		return nil, fmt.Errorf("trackio: decode %s: %w", u, err)
+	}
+	return runs, nil
+}
+
+// Subscribe streams LogItems for project/run starting after sinceStep.
+// The returned channel is closed when ctx is done or the underlying
+// stream ends; delivery errors after the subscription is established are
+// otherwise swallowed, matching Log's fire-and-forget style.
+func (r *Reader) Subscribe(ctx context.Context, project, run string, sinceStep int) (<-chan LogItem, error) {
+	if ch, err := r.subscribeSSE(ctx, project, run, sinceStep); err == nil {
+		return ch, nil
+	}
+	if ch, err := r.subscribeLongPoll(ctx, project, run, sinceStep); err == nil {
+		return ch, nil
+	}
+	return r.subscribeLegacyCall(ctx, project, run, sinceStep)
+}
+
+func (r *Reader) subscribeSSE(ctx context.Context, project, run string, sinceStep int) (<-chan LogItem, error) {
+	u := fmt.Sprintf("%s/api/runs/%s/%s/stream?since=%d", r.baseURL, url.PathEscape(project), url.PathEscape(run), sinceStep)
+
+	resp, err := doWithRedirect(ctx, r.http, http.MethodGet, u, nil, r.headers("text/event-stream"))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 || !strings.Contains(resp.Header.Get("Content-Type"), "text/event-stream") {
+		resp.Body.Close()
+		return nil, fmt.Errorf("trackio: %s does not support SSE (status %s)", u, resp.Status)
+	}
+
+	ch := make(chan LogItem)
+	go func() {
+		defer close(ch)
+		defer resp.Body.Close()
+		scanSSE(ctx, resp.Body, ch)
+	}()
+	return ch, nil
+}
+
+// scanSSE reads "data: <json>" lines terminated by a blank line, the
+// minimal subset of the SSE wire format trackio's stream endpoints need.
+// Each event's data is either one JSON LogItem or a JSON array of them;
+// events that decode as neither are skipped.
+func scanSSE(ctx context.Context, body io.Reader, ch chan<- LogItem) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	var data strings.Builder
+	for scanner.Scan() {
+		if ctx.Err() != nil {
+			return
+		}
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if data.Len() == 0 {
+				continue
+			}
+			raw := []byte(data.String())
+			var items []LogItem
+			if err := json.Unmarshal(raw, &items); err != nil {
+				var it LogItem
+				if err := json.Unmarshal(raw, &it); err == nil {
+					items = []LogItem{it}
+				}
+			}
+			for _, it := range items {
+				select {
+				case ch <- it:
+				case <-ctx.Done():
+					return
+				}
+			}
+			data.Reset()
+		case strings.HasPrefix(line, "data:"):
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+}
+
+// subscribeLongPoll polls /api/runs/{project}/{run}/metrics?since=<step>
+// in a loop, each call blocking server-side until new data or a timeout,
+// and forwards whatever items it gets back.
+func (r *Reader) subscribeLongPoll(ctx context.Context, project, run string, sinceStep int) (<-chan LogItem, error) {
+	page, err := r.pollMetrics(ctx, project, run, sinceStep)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan LogItem)
+	go func() {
+		defer close(ch)
+		since := sinceStep
+		for _, it := range page.Items {
+			select {
+			case ch <- it:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if page.NextSince > since {
+			since = page.NextSince
+		}
+
+		for {
+			if ctx.Err() != nil {
+				return
+			}
+			page, err := r.pollMetrics(ctx, project, run, since)
+			if err != nil {
+				return
+			}
+			for _, it := range page.Items {
+				select {
+				case ch <- it:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if page.NextSince > since {
+				since = page.NextSince
+			}
+		}
+	}()
+	return ch, nil
+}
+
+func (r *Reader) pollMetrics(ctx context.Context, project, run string, sinceStep int) (metricsPage, error) {
+	u := fmt.Sprintf("%s/api/runs/%s/%s/metrics?since=%d", r.baseURL, url.PathEscape(project), url.PathEscape(run), sinceStep)
+
+	resp, err := doWithRedirect(ctx, r.http, http.MethodGet, u, nil, r.headers("application/json"))
+	if err != nil {
+		return metricsPage{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return metricsPage{}, &httpStatusError{URL: u, StatusCode: resp.StatusCode, Status: resp.Status, Body: string(body)}
+	}
+
+	var page metricsPage
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return metricsPage{}, fmt.Errorf("trackio: decode %s: %w", u, err)
+	}
+	return page, nil
+}
+
+// subscribeLegacyCall speaks gradio's generic two-step call protocol: POST
+// {"data": [...]} to get an event_id, then GET that event_id's endpoint to
+// stream its "data: ..." events, one JSON LogItem array per event.
+func (r *Reader) subscribeLegacyCall(ctx context.Context, project, run string, sinceStep int) (<-chan LogItem, error) {
+	const api = "get_metrics"
+	startURL := r.baseURL + "/gradio_api/call/" + api
+
+	body, err := json.Marshal(gradioCallEnvelope{Data: []any{project, run, sinceStep}})
+	if err != nil {
+		return nil, err
+	}
+
+	headers := r.headers("application/json")
+	headers["Content-Type"] = "application/json"
+	resp, err := doWithRedirect(ctx, r.http, http.MethodPost, startURL, body, headers)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(resp.Body)
+		return nil, &httpStatusError{URL: startURL, StatusCode: resp.StatusCode, Status: resp.Status, Body: string(b)}
+	}
+
+	var started callStarted
+	if err := json.NewDecoder(resp.Body).Decode(&started); err != nil {
+		return nil, fmt.Errorf("trackio: decode %s: %w", startURL, err)
+	}
+
+	streamURL := startURL + "/" + url.PathEscape(started.EventID)
+	streamResp, err := doWithRedirect(ctx, r.http, http.MethodGet, streamURL, nil, r.headers("text/event-stream"))
+	if err != nil {
+		return nil, err
+	}
+	if streamResp.StatusCode >= 300 {
+		streamResp.Body.Close()
+		return nil, fmt.Errorf("trackio: %s returned %s", streamURL, streamResp.Status)
+	}
+
+	ch := make(chan LogItem)
+	go func() {
+		defer close(ch)
+		defer streamResp.Body.Close()
+		scanSSE(ctx, streamResp.Body, ch)
+	}()
+	return ch, nil
+}
@@ -0,0 +1,65 @@
+package trackio
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReaderList(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/runs" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode([]RunInfo{{Project: "p", Run: "r1", LastStep: 10}})
+	}))
+	defer srv.Close()
+
+	reader := NewReader(WithReaderBaseURL(srv.URL))
+	runs, err := reader.List(context.Background(), ProjectFilter{Project: "p"})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(runs) != 1 || runs[0].Run != "r1" {
+		t.Fatalf("unexpected runs: %+v", runs)
+	}
+}
+
+func TestReaderSubscribeSSE(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, "data: {\"step\":1,\"metrics\":{\"loss\":0.1}}\n\n")
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+	}))
+	defer srv.Close()
+
+	reader := NewReader(WithReaderBaseURL(srv.URL))
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	ch, err := reader.Subscribe(ctx, "p", "r1", 0)
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	select {
+	case it, ok := <-ch:
+		if !ok {
+			t.Fatal("channel closed before any item arrived")
+		}
+		if it.Metrics["loss"] != 0.1 {
+			t.Fatalf("unexpected item: %+v", it)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for item")
+	}
+}
@@ -0,0 +1,465 @@
+package trackio
+
+// spool.go
+//
+// spool is a segmented, append-only write-ahead log for LogItems.
+// enqueueContext() durably appends every item here before it ever reaches the
+// in-memory ring batcher uses for hot batching, so a crashed or restarted
+// process can resume delivery of whatever the server never acknowledged.
+//
+// On disk:
+//
+//	$TRACKIO_SPOOL_DIR/<project>/<run>/wal-000001.log
+//	$TRACKIO_SPOOL_DIR/<project>/<run>/wal-000002.log
+//	$TRACKIO_SPOOL_DIR/<project>/<run>/checkpoint
+//
+// Each wal-*.log segment is a sequence of length-prefixed JSON records (a
+// big-endian uint32 length followed by that many bytes of encoded
+// LogItem). checkpoint records how far delivery has progressed as a
+// (segment, offset) mark; segments entirely before the mark are safe to
+// delete and are pruned as soon as they are.
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// OverflowPolicy controls what append does once the spool has grown past
+// its configured max size.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock makes append() wait until room is freed by acked
+	// segments being pruned.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropOldest prunes the oldest unacked segment to make room,
+	// losing whatever items it held.
+	OverflowDropOldest
+	// OverflowDropNewest rejects the new record with ErrSpoolFull, leaving
+	// everything already on disk untouched.
+	OverflowDropNewest
+)
+
+func parseOverflowPolicy(s string) OverflowPolicy {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "drop-oldest":
+		return OverflowDropOldest
+	case "drop-newest":
+		return OverflowDropNewest
+	default:
+		return OverflowBlock
+	}
+}
+
+// ErrSpoolFull is returned by append when the spool is at capacity and the
+// overflow policy is OverflowDropNewest.
+var ErrSpoolFull = errors.New("trackio: spool is full")
+
+const (
+	segmentPrefix  = "wal-"
+	segmentSuffix  = ".log"
+	checkpointName = "checkpoint"
+)
+
+// maxSegmentSize is the threshold append rolls a new segment past. A var
+// rather than a const so tests can shrink it to exercise rolling without
+// writing megabytes of records.
+var maxSegmentSize int64 = 4 << 20
+
+// mark identifies a position in the spool: the segment file index plus
+// the byte offset immediately after a record within it.
+type mark struct {
+	Segment int   `json:"segment"`
+	Offset  int64 `json:"offset"`
+}
+
+func (m mark) after(other mark) bool {
+	return m.Segment > other.Segment || (m.Segment == other.Segment && m.Offset > other.Offset)
+}
+
+// pendingItem is a spooled LogItem (freshly appended, or recovered from
+// the spool on startup), along with the mark it was written at so the
+// batcher can ack it once delivered.
+type pendingItem struct {
+	Item LogItem
+	At   mark
+}
+
+type spool struct {
+	dir          string
+	maxSpoolSize int64
+	fsyncEvery   int
+	overflow     OverflowPolicy
+
+	mu        sync.Mutex
+	cond      *sync.Cond
+	segSizes  map[int]int64 // segment index -> size on disk
+	active    *os.File
+	activeIdx int
+	total     int64
+	unsynced  int
+	ckpt      mark
+}
+
+func defaultSpoolDir(project, run string) string {
+	root := os.Getenv("TRACKIO_SPOOL_DIR")
+	if root == "" {
+		root = filepath.Join(os.TempDir(), "trackio-spool")
+	}
+	if project == "" {
+		project = "default"
+	}
+	if run == "" {
+		run = "default"
+	}
+	return filepath.Join(root, project, run)
+}
+
+// openSpool opens (creating if necessary) the spool directory, replays
+// any segments past the last checkpoint, and returns the spool along with
+// every unacknowledged item found on disk, oldest first.
+func openSpool(dir string, maxSpoolSize int64, fsyncEvery int, overflow OverflowPolicy) (*spool, []pendingItem, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, nil, fmt.Errorf("trackio: create spool dir: %w", err)
+	}
+	if fsyncEvery < 1 {
+		fsyncEvery = 1
+	}
+
+	s := &spool{
+		dir:          dir,
+		maxSpoolSize: maxSpoolSize,
+		fsyncEvery:   fsyncEvery,
+		overflow:     overflow,
+		segSizes:     map[int]int64{},
+		ckpt:         readCheckpoint(dir),
+	}
+	s.cond = sync.NewCond(&s.mu)
+
+	indices, err := listSegments(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pending, err := s.replay(indices)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.openActive(indices); err != nil {
+		return nil, nil, err
+	}
+	s.pruneAckedLocked()
+
+	return s, pending, nil
+}
+
+func listSegments(dir string) ([]int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("trackio: list spool dir: %w", err)
+	}
+	var indices []int
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, segmentPrefix) || !strings.HasSuffix(name, segmentSuffix) {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, segmentPrefix), segmentSuffix))
+		if err != nil {
+			continue
+		}
+		indices = append(indices, n)
+	}
+	sort.Ints(indices)
+	return indices, nil
+}
+
+func segmentPath(dir string, idx int) string {
+	return filepath.Join(dir, fmt.Sprintf("%s%06d%s", segmentPrefix, idx, segmentSuffix))
+}
+
+// replay reads every record at or after the checkpoint mark from the
+// given segments and returns them as pending items to redeliver. It also
+// records each segment's on-disk size for pruning/overflow accounting.
+func (s *spool) replay(indices []int) ([]pendingItem, error) {
+	var pending []pendingItem
+	for _, idx := range indices {
+		path := segmentPath(s.dir, idx)
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("trackio: stat segment %d: %w", idx, err)
+		}
+		s.segSizes[idx] = info.Size()
+		s.total += info.Size()
+
+		if idx < s.ckpt.Segment {
+			continue // fully acked; pruneAckedLocked will remove it
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("trackio: open segment %d: %w", idx, err)
+		}
+		var start int64
+		if idx == s.ckpt.Segment {
+			start = s.ckpt.Offset
+			if _, err := f.Seek(start, io.SeekStart); err != nil {
+				f.Close()
+				return nil, fmt.Errorf("trackio: seek segment %d: %w", idx, err)
+			}
+		}
+
+		offset := start
+		r := bufio.NewReader(f)
+		for {
+			item, n, err := readRecord(r)
+			if err != nil {
+				// A short/torn record means a crash interrupted the last
+				// write; anything after it is unrecoverable, so stop
+				// replaying this segment rather than failing startup.
+				break
+			}
+			offset += int64(n)
+			pending = append(pending, pendingItem{Item: item, At: mark{Segment: idx, Offset: offset}})
+		}
+		f.Close()
+	}
+	return pending, nil
+}
+
+// openActive opens the last segment (or creates the first one) for
+// appending new records.
+func (s *spool) openActive(indices []int) error {
+	idx := 1
+	if len(indices) > 0 {
+		idx = indices[len(indices)-1]
+	}
+	f, err := os.OpenFile(segmentPath(s.dir, idx), os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("trackio: open active segment %d: %w", idx, err)
+	}
+	if _, ok := s.segSizes[idx]; !ok {
+		s.segSizes[idx] = 0
+	}
+	s.active = f
+	s.activeIdx = idx
+	return nil
+}
+
+func readRecord(r *bufio.Reader) (LogItem, int, error) {
+	var lenBuf [4]byte
+	if _, err := io.ReadFull(r, lenBuf[:]); err != nil {
+		return LogItem{}, 0, err
+	}
+	n := binary.BigEndian.Uint32(lenBuf[:])
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return LogItem{}, 0, err
+	}
+	var it LogItem
+	if err := json.Unmarshal(buf, &it); err != nil {
+		return LogItem{}, 0, err
+	}
+	return it, 4 + int(n), nil
+}
+
+func encodeRecord(it LogItem) ([]byte, error) {
+	payload, err := json.Marshal(it)
+	if err != nil {
+		return nil, err
+	}
+	rec := make([]byte, 4+len(payload))
+	binary.BigEndian.PutUint32(rec[:4], uint32(len(payload)))
+	copy(rec[4:], payload)
+	return rec, nil
+}
+
+// append durably writes it to the active segment and returns the mark it
+// was written at. It blocks, drops the oldest segment, or rejects the
+// write depending on the configured OverflowPolicy once the spool is at
+// capacity. Under OverflowBlock, the wait respects ctx: a canceled or
+// expired ctx wakes it immediately with ctx.Err() instead of blocking
+// forever on room that advance() may never free.
+func (s *spool) append(ctx context.Context, it LogItem) (mark, error) {
+	rec, err := encodeRecord(it)
+	if err != nil {
+		return mark{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for s.maxSpoolSize > 0 && s.total+int64(len(rec)) > s.maxSpoolSize {
+		switch s.overflow {
+		case OverflowDropNewest:
+			return mark{}, ErrSpoolFull
+		case OverflowDropOldest:
+			if !s.dropOldestLocked() {
+				// Nothing left to drop (single segment holding the
+				// overflow); accept the write rather than wedge forever.
+				goto write
+			}
+		default: // OverflowBlock
+			if ctx.Err() != nil {
+				return mark{}, ctx.Err()
+			}
+			if !s.waitLocked(ctx) {
+				return mark{}, ctx.Err()
+			}
+		}
+	}
+
+write:
+	if s.segSizes[s.activeIdx] > 0 && s.segSizes[s.activeIdx]+int64(len(rec)) > maxSegmentSize {
+		if err := s.rollLocked(); err != nil {
+			return mark{}, err
+		}
+	}
+
+	if _, err := s.active.Write(rec); err != nil {
+		return mark{}, fmt.Errorf("trackio: write spool record: %w", err)
+	}
+	s.segSizes[s.activeIdx] += int64(len(rec))
+	s.total += int64(len(rec))
+
+	s.unsynced++
+	if s.unsynced >= s.fsyncEvery {
+		_ = s.active.Sync()
+		s.unsynced = 0
+	}
+
+	return mark{Segment: s.activeIdx, Offset: s.segSizes[s.activeIdx]}, nil
+}
+
+// waitLocked blocks on s.cond (releasing s.mu while it does, as
+// sync.Cond.Wait always does) until either advance() frees room or ctx
+// is done, and reports whether it woke because of the former. s.mu is
+// held again on return either way, matching Wait's own contract.
+//
+// sync.Cond has no native way to select against a context, so this
+// spawns a watcher goroutine that, on ctx being done, re-acquires s.mu
+// just to Broadcast and wake the waiter, which then rechecks ctx.Err().
+func (s *spool) waitLocked(ctx context.Context) bool {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		select {
+		case <-ctx.Done():
+			s.mu.Lock()
+			s.cond.Broadcast()
+			s.mu.Unlock()
+		case <-stop:
+		}
+	}()
+	s.cond.Wait()
+	close(stop)
+	<-done
+	return ctx.Err() == nil
+}
+
+func (s *spool) rollLocked() error {
+	next := s.activeIdx + 1
+	f, err := os.OpenFile(segmentPath(s.dir, next), os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("trackio: roll spool segment: %w", err)
+	}
+	s.active.Close()
+	s.active = f
+	s.activeIdx = next
+	s.segSizes[next] = 0
+	return nil
+}
+
+// dropOldestLocked removes the oldest unacked segment that isn't the
+// active one. Reports whether a segment was actually dropped.
+func (s *spool) dropOldestLocked() bool {
+	oldest := -1
+	for idx := range s.segSizes {
+		if idx == s.activeIdx {
+			continue
+		}
+		if oldest == -1 || idx < oldest {
+			oldest = idx
+		}
+	}
+	if oldest == -1 {
+		return false
+	}
+	s.total -= s.segSizes[oldest]
+	delete(s.segSizes, oldest)
+	_ = os.Remove(segmentPath(s.dir, oldest))
+	if s.ckpt.Segment < oldest {
+		s.ckpt = mark{Segment: oldest}
+	}
+	return true
+}
+
+// advance moves the checkpoint forward to m and prunes any segments that
+// are now entirely acknowledged. Callers must only pass marks returned by
+// append, in non-decreasing order.
+func (s *spool) advance(m mark) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !m.after(s.ckpt) {
+		return
+	}
+	s.ckpt = m
+	writeCheckpoint(s.dir, m)
+	s.pruneAckedLocked()
+	s.cond.Broadcast()
+}
+
+func (s *spool) pruneAckedLocked() {
+	for idx := range s.segSizes {
+		if idx < s.ckpt.Segment && idx != s.activeIdx {
+			s.total -= s.segSizes[idx]
+			delete(s.segSizes, idx)
+			_ = os.Remove(segmentPath(s.dir, idx))
+		}
+	}
+}
+
+func (s *spool) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.active == nil {
+		return nil
+	}
+	return s.active.Close()
+}
+
+func readCheckpoint(dir string) mark {
+	b, err := os.ReadFile(filepath.Join(dir, checkpointName))
+	if err != nil {
+		return mark{Segment: 1, Offset: 0}
+	}
+	var m mark
+	if err := json.Unmarshal(b, &m); err != nil {
+		return mark{Segment: 1, Offset: 0}
+	}
+	return m
+}
+
+func writeCheckpoint(dir string, m mark) {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return
+	}
+	// Best-effort: a missed checkpoint write only costs a few redelivered
+	// items on restart, never data loss.
+	_ = os.WriteFile(filepath.Join(dir, checkpointName), b, 0o644)
+}
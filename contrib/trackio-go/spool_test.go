@@ -0,0 +1,178 @@
+package trackio
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSpoolAppendOverflowBlockRespectsCtx reproduces the case where
+// OverflowBlock's append wedges forever once the spool is at capacity:
+// with a tiny maxSpoolSize and no room ever freed (nothing acks), append
+// must still return ctx.Err() once ctx expires instead of blocking past
+// it indefinitely.
+func TestSpoolAppendOverflowBlockRespectsCtx(t *testing.T) {
+	dir := t.TempDir()
+	// maxSpoolSize=1 means even a single record overflows it immediately,
+	// and nothing ever advances the checkpoint to free room, so a
+	// blocking append would otherwise wait forever.
+	s, _, err := openSpool(dir, 1, 1, OverflowBlock)
+	if err != nil {
+		t.Fatalf("openSpool: %v", err)
+	}
+	defer s.close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = s.append(ctx, LogItem{Metrics: map[string]any{"x": "overflow"}})
+	elapsed := time.Since(start)
+
+	if err == nil {
+		t.Fatal("expected append to fail once ctx expired while blocked, got nil error")
+	}
+	if elapsed > time.Second {
+		t.Fatalf("append took %v, want it to return promptly once the 200ms ctx deadline passed", elapsed)
+	}
+}
+
+// TestSpoolResumesPendingItemsAfterRestart checks the core durability
+// promise: items appended but never advance()'d past are replayed, in
+// order, the next time the spool is opened against the same directory -
+// as if the process had crashed before delivering them.
+func TestSpoolResumesPendingItemsAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+
+	s, _, err := openSpool(dir, 0, 3, OverflowBlock)
+	if err != nil {
+		t.Fatalf("openSpool: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		if _, err := s.append(context.Background(), LogItem{Metrics: map[string]any{"x": i}}); err != nil {
+			t.Fatalf("append %d: %v", i, err)
+		}
+	}
+	if err := s.close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	_, pending, err := openSpool(dir, 0, 3, OverflowBlock)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if len(pending) != 5 {
+		t.Fatalf("got %d pending items after restart, want 5", len(pending))
+	}
+	for i, p := range pending {
+		if got := int(p.Item.Metrics["x"].(float64)); got != i {
+			t.Fatalf("pending[%d].Metrics[x] = %d, want %d", i, got, i)
+		}
+	}
+}
+
+// TestSpoolAdvancePrunesAckedSegments checks that advance() both
+// checkpoints past acked items and deletes whatever segments are now
+// entirely acked, and that a restart only resumes what's left.
+func TestSpoolAdvancePrunesAckedSegments(t *testing.T) {
+	orig := maxSegmentSize
+	maxSegmentSize = 1 // roll to a new segment on every append
+	defer func() { maxSegmentSize = orig }()
+
+	dir := t.TempDir()
+	s, _, err := openSpool(dir, 0, 1, OverflowBlock)
+	if err != nil {
+		t.Fatalf("openSpool: %v", err)
+	}
+
+	var marks []mark
+	for i := 0; i < 4; i++ {
+		m, err := s.append(context.Background(), LogItem{Metrics: map[string]any{"x": i}})
+		if err != nil {
+			t.Fatalf("append %d: %v", i, err)
+		}
+		marks = append(marks, m)
+	}
+	if marks[0].Segment == marks[3].Segment {
+		t.Fatalf("expected appends to roll across segments with maxSegmentSize=1, all landed in segment %d", marks[0].Segment)
+	}
+
+	// Ack everything up to (but not including) the last item.
+	s.advance(marks[2])
+	if err := s.close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	remaining, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("read spool dir: %v", err)
+	}
+	for _, e := range remaining {
+		if !strings.HasPrefix(e.Name(), segmentPrefix) {
+			continue
+		}
+		n, _ := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(e.Name(), segmentPrefix), segmentSuffix))
+		if n < marks[2].Segment {
+			t.Fatalf("segment %d should have been pruned after advance(%+v), still present", n, marks[2])
+		}
+	}
+
+	_, pending, err := openSpool(dir, 0, 1, OverflowBlock)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if len(pending) != 1 {
+		t.Fatalf("got %d pending items after advance+restart, want 1 (only the unacked item)", len(pending))
+	}
+	if got := int(pending[0].Item.Metrics["x"].(float64)); got != 3 {
+		t.Fatalf("pending[0].Metrics[x] = %d, want 3", got)
+	}
+}
+
+// TestSpoolOverflowDropOldest checks that once the spool is full, the
+// oldest unacked segment is dropped to make room rather than blocking or
+// rejecting the new write.
+func TestSpoolOverflowDropOldest(t *testing.T) {
+	orig := maxSegmentSize
+	maxSegmentSize = 1 // one record per segment, so "oldest segment" is well defined
+	defer func() { maxSegmentSize = orig }()
+
+	dir := t.TempDir()
+	// Small enough that the 3rd append overflows and must drop segment 1.
+	s, _, err := openSpool(dir, 80, 1, OverflowDropOldest)
+	if err != nil {
+		t.Fatalf("openSpool: %v", err)
+	}
+	defer s.close()
+
+	for i := 0; i < 3; i++ {
+		if _, err := s.append(context.Background(), LogItem{Metrics: map[string]any{"x": i}}); err != nil {
+			t.Fatalf("append %d: %v", i, err)
+		}
+	}
+
+	if _, err := os.Stat(segmentPath(dir, 1)); !os.IsNotExist(err) {
+		t.Fatalf("expected segment 1 to have been dropped to make room, stat err: %v", err)
+	}
+}
+
+// TestSpoolOverflowDropNewest checks that once the spool is full, append
+// rejects the new record with ErrSpoolFull rather than blocking or
+// losing anything already on disk.
+func TestSpoolOverflowDropNewest(t *testing.T) {
+	dir := t.TempDir()
+	s, _, err := openSpool(dir, 1, 1, OverflowDropNewest)
+	if err != nil {
+		t.Fatalf("openSpool: %v", err)
+	}
+	defer s.close()
+
+	_, err = s.append(context.Background(), LogItem{Metrics: map[string]any{"x": 1}})
+	if !errors.Is(err, ErrSpoolFull) {
+		t.Fatalf("append: got %v, want ErrSpoolFull", err)
+	}
+}